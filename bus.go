@@ -0,0 +1,33 @@
+package motorhat
+
+import "github.com/mbrumlow/i2c"
+
+// Bus is the I2C access a MotorHat needs from its transport: single
+// register reads and writes, plus a way to release the underlying device.
+// OpenWithBus accepts any implementation, including a fake for tests.
+type Bus interface {
+	WriteRegister(reg, val uint8) error
+	ReadRegister(reg uint8) (uint8, error)
+	Close() error
+}
+
+// i2cBus adapts *i2c.I2C to Bus without assuming the exact signature of
+// its Close method, since WriteRegister and ReadRegister are called for
+// their error return but the baseline only ever called Close() as a bare
+// statement.
+type i2cBus struct {
+	dev *i2c.I2C
+}
+
+func (b *i2cBus) WriteRegister(reg, val uint8) error {
+	return b.dev.WriteRegister(reg, val)
+}
+
+func (b *i2cBus) ReadRegister(reg uint8) (uint8, error) {
+	return b.dev.ReadRegister(reg)
+}
+
+func (b *i2cBus) Close() error {
+	b.dev.Close()
+	return nil
+}
@@ -0,0 +1,33 @@
+package motorhat
+
+import (
+	"testing"
+
+	"github.com/mbrumlow/motorhat/fakebus"
+)
+
+func TestForwardDrivesExpectedPins(t *testing.T) {
+
+	bus := fakebus.New()
+
+	mh, err := OpenWithBus(bus)
+	if err != nil {
+		t.Fatalf("OpenWithBus: %v", err)
+	}
+	defer mh.Close()
+
+	in1 := motorMapIn1[1]
+	in2 := motorMapIn2[1]
+
+	if err := mh.Forward(1); err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+
+	if got := bus.Reg(led0OnH + uint8(4*in1)); got != 0x10 {
+		t.Errorf("in1 onH = 0x%02x, want 0x10 (driven high)", got)
+	}
+
+	if got := bus.Reg(led0OffH + uint8(4*in2)); got != 0x10 {
+		t.Errorf("in2 offH = 0x%02x, want 0x10 (driven low)", got)
+	}
+}
@@ -0,0 +1,68 @@
+// Package fakebus is an in-memory motorhat.Bus that records every register
+// write, so motor and step sequences can be asserted against without a Pi
+// or real PCA9685 on the other end of the wire.
+package fakebus
+
+import "sync"
+
+// Write is a single recorded register write.
+type Write struct {
+	Reg uint8
+	Val uint8
+}
+
+// Bus is a motorhat.Bus backed by an in-memory register file.
+type Bus struct {
+	mu     sync.Mutex
+	regs   map[uint8]uint8
+	writes []Write
+}
+
+// New returns an empty Bus with every register reading back as zero.
+func New() *Bus {
+	return &Bus{regs: make(map[uint8]uint8)}
+}
+
+func (b *Bus) WriteRegister(reg, val uint8) error {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.regs[reg] = val
+	b.writes = append(b.writes, Write{Reg: reg, Val: val})
+
+	return nil
+}
+
+func (b *Bus) ReadRegister(reg uint8) (uint8, error) {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.regs[reg], nil
+}
+
+func (b *Bus) Close() error {
+	return nil
+}
+
+// Writes returns every register write recorded so far, in order.
+func (b *Bus) Writes() []Write {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	writes := make([]Write, len(b.writes))
+	copy(writes, b.writes)
+
+	return writes
+}
+
+// Reg returns the current value of reg, as last written by WriteRegister.
+func (b *Bus) Reg(reg uint8) uint8 {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.regs[reg]
+}
@@ -0,0 +1,31 @@
+package motorhat
+
+// Logger is a leveled, V-style sink for diagnostic traffic. V(1) covers
+// lifecycle events (init, frequency changes, close), V(2) every register
+// write, and V(3) the on/off counts computed for a PWM update.
+type Logger interface {
+	Logf(level int, format string, args ...interface{})
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Logf(level int, format string, args ...interface{}) {}
+
+// OpenOption configures a MotorHat at Open/OpenWithBus time.
+type OpenOption func(*MotorHat)
+
+// WithLogger makes Open/OpenWithBus log lifecycle events, register writes,
+// and PWM updates to l instead of discarding them.
+func WithLogger(l Logger) OpenOption {
+	return func(mh *MotorHat) {
+		if l != nil {
+			mh.logger = l
+		}
+	}
+}
+
+// writeRegister logs the register write at V(2) before issuing it.
+func (mh *MotorHat) writeRegister(reg, val uint8) error {
+	mh.logger.Logf(2, "reg=0x%02x val=0x%02x", reg, val)
+	return mh.bus.WriteRegister(reg, val)
+}
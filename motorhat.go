@@ -3,6 +3,7 @@ package motorhat
 import (
 	"fmt"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/mbrumlow/i2c"
@@ -48,17 +49,66 @@ var (
 )
 
 type MotorHat struct {
-	i2c *i2c.I2C
+	bus    Bus
+	freq   int
+	logger Logger
+
+	rampMu sync.Mutex
+	ramps  map[int]*motorRamp
+
+	configMu sync.Mutex
+	configs  map[int]MotorConfig
+
+	dutyMu sync.Mutex
+	duty   map[int]int
+}
+
+// getDuty returns the last PWM duty (0-255) written for motor m, or 0 if
+// nothing has been written yet.
+func (mh *MotorHat) getDuty(m int) int {
+
+	mh.dutyMu.Lock()
+	defer mh.dutyMu.Unlock()
+
+	return mh.duty[m]
 }
 
-func Open(addr uint8, bus int) (*MotorHat, error) {
+// setDuty records the PWM duty (0-255) last written for motor m, so a
+// later Ramp call can pick up from where the motor actually is.
+func (mh *MotorHat) setDuty(m, d int) {
+
+	mh.dutyMu.Lock()
+	defer mh.dutyMu.Unlock()
+
+	if mh.duty == nil {
+		mh.duty = make(map[int]int)
+	}
+
+	mh.duty[m] = d
+}
 
-	i2c, err := i2c.New(addr, bus)
+// Open is a convenience wrapper around OpenWithBus that talks to the board
+// over github.com/mbrumlow/i2c.
+func Open(addr uint8, bus int, opts ...OpenOption) (*MotorHat, error) {
+
+	dev, err := i2c.New(addr, bus)
 	if err != nil {
 		return nil, err
 	}
 
-	mh := &MotorHat{i2c: i2c}
+	return OpenWithBus(&i2cBus{dev: dev}, opts...)
+}
+
+// OpenWithBus initializes a MotorHat on top of an already-opened Bus,
+// letting callers swap in an alternative I2C backend or, in tests, an
+// in-memory fake.
+func OpenWithBus(bus Bus, opts ...OpenOption) (*MotorHat, error) {
+
+	mh := &MotorHat{bus: bus, logger: noopLogger{}}
+
+	for _, opt := range opts {
+		opt(mh)
+	}
 
 	if err := mh.init(); err != nil {
 		mh.Close()
@@ -70,6 +120,8 @@ func Open(addr uint8, bus int) (*MotorHat, error) {
 
 func (mh *MotorHat) init() error {
 
+	mh.logger.Logf(1, "init: starting")
+
 	if err := mh.setAllPWM(0, 0); err != nil {
 		return err
 	}
@@ -81,11 +133,38 @@ func (mh *MotorHat) init() error {
 	if err := mh.setPWMFreq(1600); err != nil {
 		return err
 	}
+	mh.freq = 1600
+
+	mh.logger.Logf(1, "init: complete, freq=%dHz", mh.freq)
+
 	return nil
 }
 
 func (mh *MotorHat) Close() {
-	mh.i2c.Close()
+	mh.logger.Logf(1, "close")
+	mh.stopAllRamps()
+	mh.bus.Close()
+}
+
+// SetMotorConfig sets motor m's defaults. When cfg.DefaultRamp is
+// non-zero, Speed transitions to its target via that ramp instead of
+// slamming the PWM value instantly.
+func (mh *MotorHat) SetMotorConfig(m int, cfg MotorConfig) error {
+
+	if _, _, _, err := getMotor(m); err != nil {
+		return err
+	}
+
+	mh.configMu.Lock()
+	defer mh.configMu.Unlock()
+
+	if mh.configs == nil {
+		mh.configs = make(map[int]MotorConfig)
+	}
+
+	mh.configs[m] = cfg
+
+	return nil
 }
 
 func (mh *MotorHat) Speed(m, s int) error {
@@ -101,7 +180,16 @@ func (mh *MotorHat) Speed(m, s int) error {
 		s = 255
 	}
 
+	mh.configMu.Lock()
+	ramp := mh.configs[m].DefaultRamp
+	mh.configMu.Unlock()
+
+	if ramp != (RampProfile{}) {
+		return mh.Ramp(m, s, ramp)
+	}
+
 	mh.setPWM(pwm, 0, s*16)
+	mh.setDuty(m, s)
 
 	return nil
 }
@@ -139,6 +227,8 @@ func (mh *MotorHat) Stop(m int) error {
 		return err
 	}
 
+	mh.stopRamp(m)
+
 	mh.setPin(in1, 0)
 	mh.setPin(in2, 0)
 
@@ -158,23 +248,23 @@ func (mh *MotorHat) setPin(pin, value int) error {
 
 func (mh *MotorHat) initPWM() error {
 
-	if err := mh.i2c.WriteRegister(mode2, outdrv); err != nil {
+	if err := mh.writeRegister(mode2, outdrv); err != nil {
 		return err
 	}
 
-	if err := mh.i2c.WriteRegister(mode1, allcall); err != nil {
+	if err := mh.writeRegister(mode1, allcall); err != nil {
 		return err
 	}
 
 	time.Sleep(5 * time.Millisecond)
 
-	m, err := mh.i2c.ReadRegister(mode1)
+	m, err := mh.bus.ReadRegister(mode1)
 	if err != nil {
 		return err
 	}
 
 	m = m &^ sleep
-	if err := mh.i2c.WriteRegister(mode1, m); err != nil {
+	if err := mh.writeRegister(mode1, m); err != nil {
 		return err
 	}
 
@@ -183,6 +273,22 @@ func (mh *MotorHat) initPWM() error {
 	return nil
 }
 
+// SetPWMFreq changes the PCA9685 PWM frequency, in Hz, for every channel on
+// the board. The four motor H-bridges are tuned for the default 1600 Hz;
+// lower it to the 40-400 Hz range expected by Servo before arming one.
+func (mh *MotorHat) SetPWMFreq(hz int) error {
+
+	mh.logger.Logf(1, "SetPWMFreq: %dHz", hz)
+
+	if err := mh.setPWMFreq(hz); err != nil {
+		return err
+	}
+
+	mh.freq = hz
+
+	return nil
+}
+
 func (mh *MotorHat) setPWMFreq(freq int) error {
 
 	ps := 25000000.0
@@ -191,7 +297,7 @@ func (mh *MotorHat) setPWMFreq(freq int) error {
 	ps -= 1.0
 	ps = math.Floor(ps + 0.05)
 
-	oldmode, err := mh.i2c.ReadRegister(mode1)
+	oldmode, err := mh.bus.ReadRegister(mode1)
 	if err != nil {
 		return err
 	}
@@ -201,7 +307,7 @@ func (mh *MotorHat) setPWMFreq(freq int) error {
 			return
 		}
 
-		err = mh.i2c.WriteRegister(r, v)
+		err = mh.writeRegister(r, v)
 	}
 
 	newmode := (oldmode & 0x7F) | sleep
@@ -220,6 +326,8 @@ func (mh *MotorHat) setPWMFreq(freq int) error {
 
 func (mh *MotorHat) setPWM(pin, on, off int) error {
 
+	mh.logger.Logf(3, "setPWM: pin=%d on=%d off=%d", pin, on, off)
+
 	var err error
 
 	writeReg := func(r, v uint8) {
@@ -227,7 +335,7 @@ func (mh *MotorHat) setPWM(pin, on, off int) error {
 			return
 		}
 
-		err = mh.i2c.WriteRegister(r, v)
+		err = mh.writeRegister(r, v)
 	}
 
 	writeReg(led0OnL+uint8(4*pin), uint8(on&0xFF))
@@ -248,7 +356,7 @@ func (mh *MotorHat) setAllPWM(on, off int) error {
 			return
 		}
 
-		err = mh.i2c.WriteRegister(r, v)
+		err = mh.writeRegister(r, v)
 	}
 
 	writeReg(allLedOnL, uint8(on&0xFF))
@@ -0,0 +1,122 @@
+package motorhat
+
+import "fmt"
+
+const pwmMax = 4095
+
+// servoFreqMin and servoFreqMax bound the PWM frequencies a hobby servo can
+// be expected to track; the motor H-bridges default to 1600 Hz, well
+// outside this range.
+const (
+	servoFreqMin = 40
+	servoFreqMax = 400
+)
+
+// freeChannels are the PCA9685 channels not wired to the four motor
+// H-bridges, broken out on the board as servo headers.
+var freeChannels = map[int]bool{
+	0:  true,
+	1:  true,
+	14: true,
+	15: true,
+}
+
+// PWMChannel is a raw PCA9685 output not used by a motor H-bridge.
+type PWMChannel struct {
+	mh      *MotorHat
+	channel int
+}
+
+// PWM returns a PWMChannel for one of the board's unused channels (0, 1,
+// 14, or 15).
+func (mh *MotorHat) PWM(channel int) (*PWMChannel, error) {
+
+	if !freeChannels[channel] {
+		return nil, fmt.Errorf("Channel not available for PWM!")
+	}
+
+	return &PWMChannel{mh: mh, channel: channel}, nil
+}
+
+// SetDutyCycle sets the channel to a duty cycle between 0 and 1.
+func (p *PWMChannel) SetDutyCycle(fraction float64) error {
+
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+
+	return p.SetOnOff(0, int(fraction*pwmMax+0.5))
+}
+
+// SetOnOff sets the raw on and off counts, each in [0, 4095], of the
+// channel's PWM cycle.
+func (p *PWMChannel) SetOnOff(on, off int) error {
+
+	return p.mh.setPWM(p.channel, on, off)
+}
+
+// Servo drives a hobby servo from one of the board's unused channels.
+type Servo struct {
+	mh      *MotorHat
+	channel int
+
+	minPulseUs int
+	maxPulseUs int
+}
+
+// Servo returns a Servo for one of the board's unused channels (0, 1, 14,
+// or 15). It refuses to arm until SetPWMFreq has put the board in the
+// 40-400 Hz range hobby servos expect.
+func (mh *MotorHat) Servo(channel int) (*Servo, error) {
+
+	if !freeChannels[channel] {
+		return nil, fmt.Errorf("Channel not available for Servo!")
+	}
+
+	if mh.freq < servoFreqMin || mh.freq > servoFreqMax {
+		return nil, fmt.Errorf("PWM frequency %d Hz out of servo range, call SetPWMFreq first!", mh.freq)
+	}
+
+	return &Servo{
+		mh:         mh,
+		channel:    channel,
+		minPulseUs: 1000,
+		maxPulseUs: 2000,
+	}, nil
+}
+
+// SetPulseRange configures the microsecond pulse widths that correspond to
+// 0 and 180 degrees in SetAngle, overriding the 1000-2000us default.
+func (s *Servo) SetPulseRange(minPulseUs, maxPulseUs int) {
+	s.minPulseUs = minPulseUs
+	s.maxPulseUs = maxPulseUs
+}
+
+// SetPulse drives the servo with a pulse of the given width, in
+// microseconds.
+func (s *Servo) SetPulse(microseconds int) error {
+
+	periodUs := 1000000.0 / float64(s.mh.freq)
+
+	off := int(float64(microseconds) / periodUs * float64(pwmMax+1))
+
+	return s.mh.setPWM(s.channel, 0, off)
+}
+
+// SetAngle drives the servo to angle degrees, 0-180, mapped onto the pulse
+// range configured by SetPulseRange.
+func (s *Servo) SetAngle(angle float64) error {
+
+	if angle < 0 {
+		angle = 0
+	} else if angle > 180 {
+		angle = 180
+	}
+
+	span := s.maxPulseUs - s.minPulseUs
+	pulse := s.minPulseUs + int(angle/180.0*float64(span))
+
+	return s.SetPulse(pulse)
+}
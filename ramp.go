@@ -0,0 +1,257 @@
+package motorhat
+
+import (
+	"math"
+	"time"
+)
+
+// rampTick is the fixed period at which a motorRamp goroutine re-evaluates
+// its profile and issues a setPWM write.
+const rampTick = 10 * time.Millisecond
+
+// RampProfile describes how Ramp transitions a motor's speed from its
+// current value to a target. Setting only SlopePerSec gives a linear ramp,
+// in PWM units (0-255) per second. Setting MaxAccel instead gives a
+// trapezoidal ramp, accelerating and braking at MaxAccel PWM units per
+// second squared; adding MaxJerk on top of MaxAccel rounds the corners of
+// that trapezoid into an S-curve by capping how fast acceleration itself
+// can change, in PWM units per second cubed.
+type RampProfile struct {
+	SlopePerSec float64
+	MaxAccel    float64
+	MaxJerk     float64
+}
+
+// MotorConfig holds per-motor defaults. DefaultRamp is the profile Speed
+// uses in place of an instant jump when set to something other than the
+// zero value.
+type MotorConfig struct {
+	DefaultRamp RampProfile
+}
+
+// Ramp transitions motor m's speed from wherever it currently is to
+// target (0-255) following profile, via a per-motor goroutine that issues
+// setPWM writes every rampTick. Calling Ramp again before the motor
+// reaches its target simply redirects it; stale updates are coalesced so
+// callers can safely spam updates from a control loop.
+func (mh *MotorHat) Ramp(m int, target int, profile RampProfile) error {
+
+	pwm, _, _, err := getMotor(m)
+	if err != nil {
+		return err
+	}
+
+	if target < 0 {
+		target = 0
+	} else if target > 255 {
+		target = 255
+	}
+
+	r := mh.rampFor(m, pwm)
+
+	update := rampUpdate{target: target, profile: profile}
+
+	select {
+	case r.updates <- update:
+	default:
+		select {
+		case <-r.updates:
+		default:
+		}
+		r.updates <- update
+	}
+
+	return nil
+}
+
+type rampUpdate struct {
+	target  int
+	profile RampProfile
+}
+
+type motorRamp struct {
+	m       int
+	pwm     int
+	updates chan rampUpdate
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func (mh *MotorHat) rampFor(m, pwm int) *motorRamp {
+
+	mh.rampMu.Lock()
+	defer mh.rampMu.Unlock()
+
+	if mh.ramps == nil {
+		mh.ramps = make(map[int]*motorRamp)
+	}
+
+	if r, ok := mh.ramps[m]; ok {
+		return r
+	}
+
+	r := &motorRamp{
+		m:       m,
+		pwm:     pwm,
+		updates: make(chan rampUpdate, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	mh.ramps[m] = r
+
+	go r.run(mh)
+
+	return r
+}
+
+// stopRamp tears down motor m's ramp goroutine, if one is running.
+func (mh *MotorHat) stopRamp(m int) {
+
+	mh.rampMu.Lock()
+	r, ok := mh.ramps[m]
+	if ok {
+		delete(mh.ramps, m)
+	}
+	mh.rampMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	close(r.stop)
+	<-r.done
+}
+
+func (mh *MotorHat) stopAllRamps() {
+
+	mh.rampMu.Lock()
+	ramps := mh.ramps
+	mh.ramps = nil
+	mh.rampMu.Unlock()
+
+	for _, r := range ramps {
+		close(r.stop)
+		<-r.done
+	}
+}
+
+func (r *motorRamp) run(mh *MotorHat) {
+
+	defer close(r.done)
+
+	ticker := time.NewTicker(rampTick)
+	defer ticker.Stop()
+
+	var target int
+	var profile RampProfile
+
+	// Seed from the duty last written for this motor, not zero, so a
+	// Ramp call on an already-spinning motor transitions from where it
+	// actually is instead of slamming it to zero on the first tick.
+	current := float64(mh.getDuty(r.m))
+	velocity := 0.0
+	accel := 0.0
+
+	for {
+		select {
+		case u := <-r.updates:
+			target = u.target
+			profile = u.profile
+
+			// Speed may have written this motor directly since the last
+			// tick; start from that duty, not the ramp's stale position.
+			if d := mh.getDuty(r.m); d != int(current+0.5) {
+				current = float64(d)
+				velocity, accel = 0, 0
+			}
+
+		case <-r.stop:
+			return
+
+		case <-ticker.C:
+
+			newCurrent, newVelocity, newAccel := stepRamp(current, velocity, accel, float64(target), profile)
+			if newCurrent == current && newVelocity == velocity && newAccel == accel {
+				continue
+			}
+			current, velocity, accel = newCurrent, newVelocity, newAccel
+
+			mh.setPWM(r.pwm, 0, int(current+0.5)*16)
+			mh.setDuty(r.m, int(current+0.5))
+		}
+	}
+}
+
+// stepRamp advances one rampTick of a ramp, returning the new position,
+// velocity, and acceleration. It is a no-op once current has settled at
+// target, so a ramp that has finished stops issuing redundant writes.
+func stepRamp(current, velocity, accel, target float64, profile RampProfile) (float64, float64, float64) {
+
+	dt := rampTick.Seconds()
+	diff := target - current
+
+	if diff == 0 && velocity == 0 && accel == 0 {
+		return target, 0, 0
+	}
+
+	switch {
+	case profile.MaxAccel > 0:
+
+		wantAccel := profile.MaxAccel
+		stoppingDist := (velocity * velocity) / (2 * profile.MaxAccel)
+
+		if math.Abs(diff) <= stoppingDist {
+			wantAccel = -profile.MaxAccel
+		}
+
+		if diff < 0 {
+			wantAccel = -wantAccel
+		}
+
+		if profile.MaxJerk > 0 {
+			maxDelta := profile.MaxJerk * dt
+			if wantAccel > accel+maxDelta {
+				wantAccel = accel + maxDelta
+			} else if wantAccel < accel-maxDelta {
+				wantAccel = accel - maxDelta
+			}
+		}
+
+		accel = wantAccel
+		velocity += accel * dt
+		current += velocity * dt
+
+	case profile.SlopePerSec > 0:
+
+		step := profile.SlopePerSec * dt
+		if step > math.Abs(diff) {
+			step = math.Abs(diff)
+		}
+
+		if diff < 0 {
+			step = -step
+		}
+
+		current += step
+		velocity = 0
+		accel = 0
+
+	default:
+		return target, 0, 0
+	}
+
+	if (diff > 0 && current > target) || (diff < 0 && current < target) {
+		current = target
+		velocity = 0
+		accel = 0
+	}
+
+	if current < 0 {
+		current = 0
+	} else if current > 255 {
+		current = 255
+	}
+
+	return current, velocity, accel
+}
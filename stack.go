@@ -0,0 +1,136 @@
+package motorhat
+
+import (
+	"fmt"
+
+	"github.com/mbrumlow/i2c"
+)
+
+const (
+	motorsPerBoard       = 4
+	stepperPortsPerBoard = 2
+)
+
+// StackAddrMin and StackAddrMax bound the solder-jumper address block the
+// MotorHat uses when multiple boards are stacked on one I2C bus.
+const (
+	StackAddrMin = 0x60
+	StackAddrMax = 0x7F
+)
+
+// Stack addresses several stacked MotorHat boards as one contiguous pool of
+// motors and steppers.
+type Stack struct {
+	boards []*MotorHat
+}
+
+// NewStack builds a Stack out of already-opened boards, in stacking order.
+func NewStack(boards ...*MotorHat) *Stack {
+	return &Stack{boards: boards}
+}
+
+// Discover probes every address in addrs on bus with a cheap read of
+// mode1 and keeps only the boards that ACK, so a program written against
+// a Stack runs unchanged whether one board or several are attached.
+// Addresses that don't respond are left untouched; only ones that ACK
+// are opened for use.
+func Discover(bus int, addrs ...uint8) (*Stack, error) {
+
+	var boards []*MotorHat
+
+	for _, addr := range addrs {
+
+		dev, err := i2c.New(addr, bus)
+		if err != nil {
+			continue
+		}
+
+		if _, err := dev.ReadRegister(mode1); err != nil {
+			dev.Close()
+			continue
+		}
+
+		mh, err := OpenWithBus(&i2cBus{dev: dev})
+		if err != nil {
+			continue
+		}
+
+		boards = append(boards, mh)
+	}
+
+	if len(boards) == 0 {
+		return nil, fmt.Errorf("No MotorHat boards found!")
+	}
+
+	return &Stack{boards: boards}, nil
+}
+
+// Close closes every board in the stack.
+func (s *Stack) Close() {
+	for _, mh := range s.boards {
+		mh.Close()
+	}
+}
+
+// Motor returns the DC motor at globalIndex, numbered 1-4 on the first
+// board, 5-8 on the second, and so on.
+func (s *Stack) Motor(globalIndex int) (*Motor, error) {
+
+	boardIdx, local, err := s.locate(globalIndex, motorsPerBoard)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Motor{mh: s.boards[boardIdx], index: local}, nil
+}
+
+// Stepper returns the stepper port at globalIndex, numbered 1-2 on the
+// first board, 3-4 on the second, and so on.
+func (s *Stack) Stepper(globalIndex int, stepsPerRev int) (*Stepper, error) {
+
+	boardIdx, local, err := s.locate(globalIndex, stepperPortsPerBoard)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.boards[boardIdx].Stepper(local, stepsPerRev)
+}
+
+func (s *Stack) locate(globalIndex, perBoard int) (int, int, error) {
+
+	if globalIndex < 1 || len(s.boards) == 0 {
+		return 0, 0, fmt.Errorf("Stack index not found!")
+	}
+
+	zero := globalIndex - 1
+	boardIdx := zero / perBoard
+	local := zero%perBoard + 1
+
+	if boardIdx >= len(s.boards) {
+		return 0, 0, fmt.Errorf("Stack index not found!")
+	}
+
+	return boardIdx, local, nil
+}
+
+// Motor is a DC motor reached through a Stack.
+type Motor struct {
+	mh    *MotorHat
+	index int
+}
+
+func (m *Motor) Forward() error {
+	return m.mh.Forward(m.index)
+}
+
+func (m *Motor) Backward() error {
+	return m.mh.Backward(m.index)
+}
+
+func (m *Motor) Stop() error {
+	return m.mh.Stop(m.index)
+}
+
+func (m *Motor) Speed(s int) error {
+	return m.mh.Speed(m.index, s)
+}
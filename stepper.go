@@ -0,0 +1,331 @@
+package motorhat
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Direction selects which way a Stepper turns.
+type Direction int
+
+const (
+	Forward Direction = iota
+	Backward
+)
+
+// StepStyle selects how the two coils of a stepper are sequenced.
+type StepStyle int
+
+const (
+	Single StepStyle = iota
+	Double
+	Interleave
+	Microstep
+)
+
+const defaultMicrostepsPerStep = 8
+
+// Stepper drives a bipolar stepper motor wired across the two H-bridges of
+// a motor port pair (M1+M2 or M3+M4).
+type Stepper struct {
+	mh *MotorHat
+
+	pwmA, in1A, in2A int
+	pwmB, in1B, in2B int
+
+	stepsPerRev       int
+	microstepsPerStep int
+
+	currentStep int
+	delay       time.Duration
+}
+
+// Stepper returns a Stepper driven by the given port, 1 for M1+M2 or 2 for
+// M3+M4, with stepsPerRev full steps per revolution of the motor.
+func (mh *MotorHat) Stepper(port int, stepsPerRev int) (*Stepper, error) {
+
+	var mA, mB int
+
+	switch port {
+	case 1:
+		mA, mB = 1, 2
+	case 2:
+		mA, mB = 3, 4
+	default:
+		return nil, fmt.Errorf("Stepper port not found!")
+	}
+
+	pwmA, in1A, in2A, err := getMotor(mA)
+	if err != nil {
+		return nil, err
+	}
+
+	pwmB, in1B, in2B, err := getMotor(mB)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Stepper{
+		mh:                mh,
+		pwmA:              pwmA,
+		in1A:              in1A,
+		in2A:              in2A,
+		pwmB:              pwmB,
+		in1B:              in1B,
+		in2B:              in2B,
+		stepsPerRev:       stepsPerRev,
+		microstepsPerStep: defaultMicrostepsPerStep,
+	}
+
+	return s, nil
+}
+
+// SetMicrostepsPerStep sets the microstep resolution used by Microstep
+// style, 8 or 16.
+func (s *Stepper) SetMicrostepsPerStep(microsteps int) error {
+
+	if microsteps != 8 && microsteps != 16 {
+		return fmt.Errorf("Microsteps per step must be 8 or 16!")
+	}
+
+	s.microstepsPerStep = microsteps
+
+	return nil
+}
+
+// SetSpeed sets the target speed, in RPM, that Step paces itself to
+// whatever style it is given; the per-full-step delay is divided among the
+// sub-steps of Interleave and Microstep.
+func (s *Stepper) SetSpeed(rpm float64) error {
+
+	if rpm <= 0 {
+		return fmt.Errorf("Speed must be greater than zero!")
+	}
+
+	secPerStep := 60.0 / (float64(s.stepsPerRev) * rpm)
+
+	s.delay = time.Duration(secPerStep * float64(time.Second))
+
+	return nil
+}
+
+// Step moves the stepper the given number of full steps in dir using
+// style, pacing between sub-steps according to the delay set by
+// SetSpeed. Interleave and Microstep sequence in half-steps and
+// microsteps respectively, so onestep is called subStepsPerStep(style)
+// times per requested step to actually cover the requested distance.
+func (s *Stepper) Step(steps int, dir Direction, style StepStyle) error {
+
+	subSteps := steps * s.subStepsPerStep(style)
+
+	for i := 0; i < subSteps; i++ {
+
+		if err := s.onestep(dir, style); err != nil {
+			return err
+		}
+
+		if s.delay > 0 {
+			time.Sleep(s.stepDelay(style))
+		}
+	}
+
+	return nil
+}
+
+// subStepsPerStep returns how many onestep calls make up one full step in
+// style.
+func (s *Stepper) subStepsPerStep(style StepStyle) int {
+
+	switch style {
+	case Interleave:
+		return 2
+	case Microstep:
+		return s.microstepsPerStep
+	default:
+		return 1
+	}
+}
+
+// stepDelay returns the pacing delay for a single call to onestep, which
+// for Interleave and Microstep advances the sequence faster than a single
+// full step.
+func (s *Stepper) stepDelay(style StepStyle) time.Duration {
+
+	switch style {
+	case Interleave:
+		return s.delay / 2
+	case Microstep:
+		return s.delay / time.Duration(s.microstepsPerStep)
+	default:
+		return s.delay
+	}
+}
+
+// Stop releases both coils so the stepper freewheels.
+func (s *Stepper) Stop() error {
+
+	if err := s.coil(0, 0); err != nil {
+		return err
+	}
+
+	return s.coil(1, 0)
+}
+
+// singleTable and doubleTable are the standard 4-state single-coil and
+// two-coil-on sequences, indexed [state][coil].
+var singleTable = [4][2]int{
+	{1, 0},
+	{0, 1},
+	{-1, 0},
+	{0, -1},
+}
+
+var doubleTable = [4][2]int{
+	{1, 1},
+	{-1, 1},
+	{-1, -1},
+	{1, -1},
+}
+
+// interleaveTable is the 8-state half-step sequence alternating between
+// single and double coil states.
+var interleaveTable = [8][2]int{
+	{1, 0},
+	{1, 1},
+	{0, 1},
+	{-1, 1},
+	{-1, 0},
+	{-1, -1},
+	{0, -1},
+	{1, -1},
+}
+
+func (s *Stepper) onestep(dir Direction, style StepStyle) error {
+
+	switch style {
+	case Single:
+		return s.stepTable(singleTable[:], 4, dir)
+	case Double:
+		return s.stepTable(doubleTable[:], 4, dir)
+	case Interleave:
+		return s.stepInterleave(dir)
+	case Microstep:
+		return s.stepMicrostep(dir)
+	}
+
+	return fmt.Errorf("Unknown step style!")
+}
+
+func (s *Stepper) stepTable(table [][2]int, n int, dir Direction) error {
+
+	if dir == Forward {
+		s.currentStep = (s.currentStep + 1) % n
+	} else {
+		s.currentStep = (s.currentStep - 1 + n) % n
+	}
+
+	state := table[s.currentStep]
+
+	return s.drive(state[0], state[1])
+}
+
+func (s *Stepper) stepInterleave(dir Direction) error {
+
+	if dir == Forward {
+		s.currentStep = (s.currentStep + 1) % len(interleaveTable)
+	} else {
+		s.currentStep = (s.currentStep - 1 + len(interleaveTable)) % len(interleaveTable)
+	}
+
+	state := interleaveTable[s.currentStep]
+
+	return s.drive(state[0], state[1])
+}
+
+// stepMicrostep advances a sine-weighted PWM curve across microstepsPerStep
+// positions per quadrant, four quadrants per revolution of the table.
+func (s *Stepper) stepMicrostep(dir Direction) error {
+
+	n := s.microstepsPerStep * 4
+
+	if dir == Forward {
+		s.currentStep = (s.currentStep + 1) % n
+	} else {
+		s.currentStep = (s.currentStep - 1 + n) % n
+	}
+
+	theta := 2.0 * math.Pi * float64(s.currentStep) / float64(n)
+
+	return s.driveMicrostep(math.Cos(theta), math.Sin(theta))
+}
+
+// drive sets each coil to fully on, fully off, or reversed polarity with
+// the PWM channel held high.
+func (s *Stepper) drive(a, b int) error {
+
+	if err := s.coil(0, a); err != nil {
+		return err
+	}
+
+	return s.coil(1, b)
+}
+
+func (s *Stepper) coil(which, value int) error {
+
+	pwm, in1, in2 := s.pwmA, s.in1A, s.in2A
+	if which == 1 {
+		pwm, in1, in2 = s.pwmB, s.in1B, s.in2B
+	}
+
+	switch {
+	case value > 0:
+		s.mh.setPWM(pwm, 0, 4095)
+		s.mh.setPin(in1, 1)
+		s.mh.setPin(in2, 0)
+	case value < 0:
+		s.mh.setPWM(pwm, 0, 4095)
+		s.mh.setPin(in1, 0)
+		s.mh.setPin(in2, 1)
+	default:
+		s.mh.setPWM(pwm, 0, 0)
+		s.mh.setPin(in1, 0)
+		s.mh.setPin(in2, 0)
+	}
+
+	return nil
+}
+
+// driveMicrostep weights the PWM duty of each coil by a and b (in
+// [-1, 1]) while selecting polarity via the IN pins, producing the
+// sine-weighted microstep curve.
+func (s *Stepper) driveMicrostep(a, b float64) error {
+
+	if err := s.coilMicrostep(0, a); err != nil {
+		return err
+	}
+
+	return s.coilMicrostep(1, b)
+}
+
+func (s *Stepper) coilMicrostep(which int, value float64) error {
+
+	pwm, in1, in2 := s.pwmA, s.in1A, s.in2A
+	if which == 1 {
+		pwm, in1, in2 = s.pwmB, s.in1B, s.in2B
+	}
+
+	duty := int(math.Abs(value)*4095.0 + 0.5)
+
+	s.mh.setPWM(pwm, 0, duty)
+
+	if value >= 0 {
+		s.mh.setPin(in1, 1)
+		s.mh.setPin(in2, 0)
+	} else {
+		s.mh.setPin(in1, 0)
+		s.mh.setPin(in2, 1)
+	}
+
+	return nil
+}